@@ -13,6 +13,7 @@ import (
 	"github.com/streamingfast/bstream/blockstream"
 	"github.com/streamingfast/dgrpc"
 	"github.com/streamingfast/dlauncher/launcher"
+	"github.com/streamingfast/dstore"
 	"github.com/streamingfast/logging"
 	"github.com/streamingfast/node-manager/mindreader"
 
@@ -33,12 +34,14 @@ const (
 	modeNode  = "node"  // Consume events from the spawned node process
 )
 
-var ingestorLogger, ingestorTracer = logging.PackageLogger("ingestor", "github.com/figment-network/firehose-cosmos/noderunner")
+var ingestorLogger, ingestorTracer = wrapWithDebugGate("ingestor", "github.com/figment-network/firehose-cosmos/noderunner")
 
 func init() {
 	appLogger := ingestorLogger
 	appTracer := ingestorTracer
 
+	installDebugSignalHandler()
+
 	registerFlags := func(cmd *cobra.Command) error {
 		flags := cmd.Flags()
 
@@ -53,11 +56,24 @@ func init() {
 		flags.String("ingestor-node-args", "", "Node process arguments")
 		flags.String("ingestor-node-env", "", "Node process env vars")
 		flags.String("ingestor-node-logs-filter", "", "Node process log filter expression")
+		flags.String("ingestor-http-listen-addr", "", "If non-empty, HTTP control API listen address (maintenance/resume/shutdown/healthz) for the spawned node, only used in 'node' mode")
+		flags.String("ingestor-oneblock-store-urls", "", "Comma-separated list of dstore URLs that should each receive a copy of every produced one-block file, for RF>1 durability. When empty, falls back to the single store pointed to by the common one-block store URL")
+		flags.Int("ingestor-oneblock-rf", 1, "Total number of one-block store copies, counting the primary one-block store plus every entry in ingestor-oneblock-store-urls; must match 1+len(ingestor-oneblock-store-urls) when the latter is set")
+		flags.Int("ingestor-oneblock-write-quorum", 1, "Number of copies (primary included) that must be durable before a produced block is acknowledged; the remaining replicas keep retrying asynchronously")
+		flags.String("ingestor-http-blocks-listen-addr", "", "If non-empty, listen address for a read-only HTTP/REST bridge serving one-block files and head info from the one-block store")
+		flags.String("ingestor-debug", "", "Comma-separated glob patterns (e.g. \"ingestor.*,codec.*\") matched against package logger names, flipping matching loggers to debug level at startup; equivalent to the DEBUG env var. Only loggers created through wrapWithDebugGate (currently \"ingestor\") are affected")
+		flags.String("ingestor-debug-http-listen-addr", "", "If non-empty, listen address for POST /v1/log?pattern=... to change the debug logging patterns at runtime, in every ingestor mode")
 
 		return nil
 	}
 
 	initFunc := func(runtime *launcher.Runtime) (err error) {
+		debugPatterns := viper.GetString("ingestor-debug")
+		if debugPatterns == "" {
+			debugPatterns = os.Getenv("DEBUG")
+		}
+		applyDebugPatterns(debugPatterns)
+
 		mode := viper.GetString("ingestor-mode")
 
 		switch mode {
@@ -92,6 +108,24 @@ func init() {
 			return blockStreamServer.Ready(), nil, nil
 		}
 
+		oneBlockReplicator, err := newOneBlockReplicatorFromFlags(oneBlockStoreURL, oneBlockFileSuffix, appLogger)
+		if err != nil {
+			log.Fatal("error initialising one-block replicator", zap.Error(err))
+			return nil, nil
+		}
+
+		blockUpdater := headBlockUpdater
+		if oneBlockReplicator != nil {
+			blockUpdater = func(block *bstream.Block) error {
+				if err := headBlockUpdater(block); err != nil {
+					return err
+				}
+				// The primary copy is already durable at this point (mindreader only
+				// calls the updater after writing it), so it counts towards quorum.
+				return oneBlockReplicator.enqueue(block)
+			}
+		}
+
 		server := dgrpc.NewServer2(
 			dgrpc.WithLogger(appLogger),
 			dgrpc.WithHealthCheck(dgrpc.HealthCheckOverGRPC|dgrpc.HealthCheckOverHTTP, healthCheck),
@@ -108,7 +142,7 @@ func init() {
 			batchStartBlockNum,
 			batchStopBlockNum,
 			blocksChanCapacity,
-			headBlockUpdater,
+			blockUpdater,
 			func(error) {},
 			oneBlockFileSuffix,
 			blockStreamServer,
@@ -120,20 +154,38 @@ func init() {
 			return nil, nil
 		}
 
+		httpBlocksListenAddr := viper.GetString("ingestor-http-blocks-listen-addr")
+
+		var blocksServer *blocksHTTPServer
+		if httpBlocksListenAddr != "" {
+			blocksReadStore, err := dstore.NewStore(oneBlockStoreURL, oneBlockFileSuffix, "", true)
+			if err != nil {
+				log.Fatal("error opening one-block store for the HTTP blocks bridge", zap.Error(err))
+				return nil, nil
+			}
+			blocksServer = newBlocksHTTPServer(blocksReadStore, oneBlockFileSuffix, blockStreamServer, appLogger)
+		}
+
 		return &IngestorApp{
-			Shutter:          shutter.New(),
-			mrp:              mrp,
-			mode:             viper.GetString("ingestor-mode"),
-			lineBufferSize:   viper.GetInt("ingestor-line-buffer-size"),
-			nodeBinPath:      viper.GetString("ingestor-node-path"),
-			nodeDir:          viper.GetString("ingestor-node-dir"),
-			nodeArgs:         viper.GetString("ingestor-node-args"),
-			nodeEnv:          viper.GetString("ingestor-node-env"),
-			nodeLogsFilter:   viper.GetString("ingestor-node-logs-filter"),
-			logsDir:          viper.GetString("ingestor-logs-dir"),
-			logsFilePattern:  viper.GetString("ingestor-logs-pattern"),
-			server:           server,
-			serverListenAddr: gprcListenAdrr,
+			Shutter:              shutter.New(),
+			mrp:                  mrp,
+			mode:                 viper.GetString("ingestor-mode"),
+			lineBufferSize:       viper.GetInt("ingestor-line-buffer-size"),
+			nodeBinPath:          viper.GetString("ingestor-node-path"),
+			nodeDir:              viper.GetString("ingestor-node-dir"),
+			nodeArgs:             viper.GetString("ingestor-node-args"),
+			nodeEnv:              viper.GetString("ingestor-node-env"),
+			nodeLogsFilter:       viper.GetString("ingestor-node-logs-filter"),
+			logsDir:              viper.GetString("ingestor-logs-dir"),
+			logsFilePattern:      viper.GetString("ingestor-logs-pattern"),
+			server:               server,
+			serverListenAddr:     gprcListenAdrr,
+			httpListenAddr:       viper.GetString("ingestor-http-listen-addr"),
+			oneBlockReplicator:   oneBlockReplicator,
+			httpBlocksListenAddr: httpBlocksListenAddr,
+			blocksServer:         blocksServer,
+			debugHTTPListenAddr:  viper.GetString("ingestor-debug-http-listen-addr"),
+			logger:               appLogger,
 		}, nil
 	}
 
@@ -149,6 +201,114 @@ func init() {
 	})
 }
 
+// IngestorApp reads blocks from a spawned node's stdout and serves them over
+// the gRPC blockstream API (and, when `--ingestor-http-listen-addr` is set
+// and the mode is `node`, exposes an HTTP control API used to manage the
+// spawned node at runtime). Only `node` mode reads blocks: in `stdin` and
+// `logs` modes Run returns right away without reading any input.
+type IngestorApp struct {
+	*shutter.Shutter
+
+	mrp  *mindreader.MindReaderPlugin
+	mode string
+
+	lineBufferSize int
+
+	nodeBinPath    string
+	nodeDir        string
+	nodeArgs       string
+	nodeEnv        string
+	nodeLogsFilter string
+
+	logsDir         string
+	logsFilePattern string
+
+	server           *dgrpc.Server
+	serverListenAddr string
+
+	httpListenAddr string
+	supervisor     *nodeSupervisor
+
+	oneBlockReplicator *oneBlockReplicator
+
+	httpBlocksListenAddr string
+	blocksServer         *blocksHTTPServer
+
+	debugHTTPListenAddr string
+
+	logger *zap.Logger
+}
+
+func (a *IngestorApp) Run() error {
+	a.OnTerminating(func(_ error) {
+		if a.supervisor != nil {
+			a.supervisor.shutdown()
+		}
+		if a.oneBlockReplicator != nil {
+			a.oneBlockReplicator.close()
+		}
+	})
+
+	go a.server.Serve(a.serverListenAddr)
+
+	if a.blocksServer != nil {
+		go func() {
+			if err := a.blocksServer.serve(a.httpBlocksListenAddr); err != nil {
+				a.Shutdown(fmt.Errorf("http blocks bridge server failed: %w", err))
+			}
+		}()
+	}
+
+	if a.debugHTTPListenAddr != "" {
+		go func() {
+			if err := serveDebugLogHTTP(a.debugHTTPListenAddr); err != nil {
+				a.Shutdown(fmt.Errorf("debug log http server failed: %w", err))
+			}
+		}()
+	}
+
+	switch a.mode {
+	case modeNode:
+		return a.runNode()
+	case modeStdin:
+		// TODO: will need to be implemented somewhere
+		return nil
+	case modeLogs:
+		// TODO: will need to be implemented somewhere
+		return nil
+	default:
+		return fmt.Errorf("invalid mode: %v", a.mode)
+	}
+}
+
+func (a *IngestorApp) runNode() error {
+	a.supervisor = newNodeSupervisor(nodeSupervisorOptions{
+		binPath: a.nodeBinPath,
+		dir:     a.nodeDir,
+		args:    a.nodeArgs,
+		env:     a.nodeEnv,
+		mrp:     a.mrp,
+		onShutdownRequested: func() {
+			a.Shutdown(nil)
+		},
+		logger: a.logger,
+	})
+
+	if a.httpListenAddr != "" {
+		go func() {
+			if err := a.supervisor.serveHTTP(a.httpListenAddr); err != nil {
+				a.Shutdown(fmt.Errorf("ingestor http control server failed: %w", err))
+			}
+		}()
+	}
+
+	return a.supervisor.resume(nil, true)
+}
+
+func (a *IngestorApp) IsReady() bool {
+	return true
+}
+
 func headBlockUpdater(_ *bstream.Block) error {
 	// TODO: will need to be implemented somewhere
 	return nil
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/streamingfast/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// registeredPackageLogger lets us flip a single package logger to debug
+// level at runtime, independently of the others, by matching patterns
+// against its short name (the first argument to logging.PackageLogger,
+// e.g. "ingestor") — the same name operators use in DEBUG/--ingestor-debug
+// (e.g. "ingestor.*,codec.*"). Only loggers created through
+// wrapWithDebugGate are registered here; loggers obtained directly from
+// logging.PackageLogger are never flipped by a pattern.
+type registeredPackageLogger struct {
+	name  string
+	debug *atomic.Bool
+}
+
+var packageLoggerRegistry []*registeredPackageLogger
+
+// effectiveDebugPattern caches the pattern currently in effect (from
+// --ingestor-debug or DEBUG, whichever initFunc picked) so the SIGUSR1
+// handler can re-apply it without falling back to a bare env var read
+// that would silently drop a flag-provided pattern.
+var effectiveDebugPattern atomic.Value
+
+func init() {
+	effectiveDebugPattern.Store("")
+}
+
+// wrapWithDebugGate behaves like logging.PackageLogger, but splices a
+// debugGateCore in front of each returned logger so its level can be
+// bumped to debug at runtime via applyDebugPatterns, without touching any
+// other registered package logger.
+func wrapWithDebugGate(name, regexPath string) (*zap.Logger, *zap.Logger) {
+	logger, tracer := logging.PackageLogger(name, regexPath)
+
+	debug := &atomic.Bool{}
+	packageLoggerRegistry = append(packageLoggerRegistry, &registeredPackageLogger{
+		name:  name,
+		debug: debug,
+	})
+
+	gate := func(core zapcore.Core) zapcore.Core {
+		return &debugGateCore{Core: core, debug: debug}
+	}
+
+	return logger.WithOptions(zap.WrapCore(gate)), tracer.WithOptions(zap.WrapCore(gate))
+}
+
+// debugGateCore forces a wrapped core to accept debug-level entries while
+// debug is true, leaving its configured level untouched otherwise.
+type debugGateCore struct {
+	zapcore.Core
+	debug *atomic.Bool
+}
+
+func (c *debugGateCore) Enabled(lvl zapcore.Level) bool {
+	if c.debug.Load() && lvl >= zapcore.DebugLevel {
+		return true
+	}
+	return c.Core.Enabled(lvl)
+}
+
+func (c *debugGateCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *debugGateCore) With(fields []zapcore.Field) zapcore.Core {
+	return &debugGateCore{Core: c.Core.With(fields), debug: c.debug}
+}
+
+// applyDebugPatterns flips every registered package logger whose short
+// name matches one of the comma-separated glob patterns (e.g.
+// "ingestor.*,codec.*") to debug level, and every other one back to its
+// configured level. A pattern ending in ".*" also matches the bare name
+// before it, so "ingestor.*" covers the "ingestor" logger itself. It also
+// becomes the pattern SIGUSR1 re-applies.
+func applyDebugPatterns(patterns string) {
+	effectiveDebugPattern.Store(patterns)
+
+	globs := strings.FieldsFunc(patterns, func(r rune) bool { return r == ',' })
+
+	for _, entry := range packageLoggerRegistry {
+		matched := false
+		for _, glob := range globs {
+			glob = strings.TrimSpace(glob)
+			ok, _ := path.Match(glob, entry.name)
+			if !ok && strings.HasSuffix(glob, ".*") {
+				ok, _ = path.Match(strings.TrimSuffix(glob, ".*"), entry.name)
+			}
+			if ok {
+				matched = true
+				break
+			}
+		}
+		entry.debug.Store(matched)
+	}
+}
+
+// installDebugSignalHandler makes SIGUSR1 re-apply whatever pattern is
+// currently in effect (the last one set via --ingestor-debug, DEBUG, or the
+// HTTP endpoint), so it never silently reverts to an empty DEBUG env var.
+// Since that pattern is already applied, the signal leaves every logger's
+// level as it was; changing the pattern at runtime goes through the HTTP
+// endpoint.
+func installDebugSignalHandler() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR1)
+
+	go func() {
+		for range sigCh {
+			applyDebugPatterns(effectiveDebugPattern.Load().(string))
+		}
+	}()
+}
+
+// serveDebugLogHTTP exposes POST /v1/log?pattern=... on its own listener,
+// independently of the node supervisor's control API, so the debug toggle
+// works regardless of --ingestor-mode.
+func serveDebugLogHTTP(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/log", handleSetLogPattern)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+func handleSetLogPattern(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	applyDebugPatterns(r.URL.Query().Get("pattern"))
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/streamingfast/dbin"
+	"github.com/streamingfast/dstore"
+	pbheadinfo "github.com/streamingfast/pbgo/sf/headinfo/v1"
+	"go.uber.org/zap"
+)
+
+// blocksHTTPServer is a read-only REST bridge over the one-block store,
+// serving the same content as the gRPC blockstream API for operators and
+// lightweight consumers that would rather curl a block than speak gRPC.
+// It reads from the same store the mind reader writes into, so there is no
+// separate indexing or duplication of data.
+type blocksHTTPServer struct {
+	store          dstore.Store
+	suffix         string
+	headInfoServer pbheadinfo.HeadInfoServer
+	logger         *zap.Logger
+}
+
+func newBlocksHTTPServer(store dstore.Store, suffix string, headInfoServer pbheadinfo.HeadInfoServer, logger *zap.Logger) *blocksHTTPServer {
+	return &blocksHTTPServer{
+		store:          store,
+		suffix:         suffix,
+		headInfoServer: headInfoServer,
+		logger:         logger,
+	}
+}
+
+func (s *blocksHTTPServer) serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blocks/", s.handleBlocks)
+	mux.HandleFunc("/head", s.handleHead)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+// handleBlocks serves GET /blocks/{num} and GET /blocks/{from}-{to}.
+func (s *blocksHTTPServer) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/blocks/")
+	from, to, err := parseBlockRange(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Read the first block before negotiating an encoding: that's the point
+	// at which we still know whether this is a clean 404 (no Content-Encoding
+	// header sent yet, plain text body) or a response we're committed to
+	// streaming.
+	first, err := s.readBlockProto(r.Context(), from)
+	if err != nil {
+		s.logger.Warn("failed reading one-block file", zap.Uint64("block_num", from), zap.Error(err))
+		http.Error(w, fmt.Sprintf("block %d not found: %v", from, err), http.StatusNotFound)
+		return
+	}
+
+	out := negotiateEncoding(w, r)
+	defer out.Close()
+
+	if err := writeBlockPayload(out, first, from != to); err != nil {
+		return
+	}
+
+	for num := from + 1; num <= to; num++ {
+		payload, err := s.readBlockProto(r.Context(), num)
+		if err != nil {
+			s.logger.Warn("failed reading one-block file", zap.Uint64("block_num", num), zap.Error(err))
+			// Headers (and some block bytes) are already on the wire: there is
+			// no way to report this in-band without corrupting the stream, so
+			// abort the connection instead of splicing in an error message or
+			// letting `out.Close()` append a bogus compression trailer. The
+			// deferred out.Close() above still runs to release the encoder's
+			// resources (e.g. the zstd writer's worker goroutines); any bytes
+			// it tries to flush land on the already-closed connection and are
+			// silently discarded.
+			s.abort(w)
+			return
+		}
+
+		if err := writeBlockPayload(out, payload, true); err != nil {
+			return
+		}
+	}
+}
+
+// writeBlockPayload writes a single block's payload to out, prefixed with
+// its big-endian uint32 length when lengthPrefixed is true (used for every
+// block in a multi-block range response so clients can split the stream).
+func writeBlockPayload(out io.Writer, payload []byte, lengthPrefixed bool) error {
+	if lengthPrefixed {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(payload)))
+		if _, err := out.Write(length[:]); err != nil {
+			return err
+		}
+	}
+
+	_, err := out.Write(payload)
+	return err
+}
+
+// abort forcibly closes the underlying connection, used when a range
+// response has already started streaming and a later block turns out to be
+// missing.
+func (s *blocksHTTPServer) abort(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	conn.Close()
+}
+
+func (s *blocksHTTPServer) handleHead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp, err := s.headInfoServer.GetHeadInfo(r.Context(), &pbheadinfo.HeadInfoRequest{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+var errStopWalk = fmt.Errorf("stop walk")
+
+// readBlockProto returns the raw protobuf bstream.Block payload for num,
+// decoding the dbin envelope and zstd compression that the one-block file
+// is stored under (the same format oneBlockFileName produces).
+func (s *blocksHTTPServer) readBlockProto(ctx context.Context, num uint64) ([]byte, error) {
+	raw, err := s.readOneBlockFile(ctx, num)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zstd.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("opening zstd stream: %w", err)
+	}
+	defer zr.Close()
+
+	decompressed, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing one-block file: %w", err)
+	}
+
+	dbinReader := dbin.NewReader(bytes.NewReader(decompressed))
+	if _, _, err := dbinReader.ReadHeader(); err != nil {
+		return nil, fmt.Errorf("reading dbin header: %w", err)
+	}
+
+	message, err := dbinReader.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("reading dbin message: %w", err)
+	}
+
+	return message, nil
+}
+
+// readOneBlockFile locates the one-block file for num by its zero-padded
+// numeric prefix and returns its raw (dbin+zstd encoded) content.
+//
+// Known limitation: one-block files are named (num, id, previousID, libNum)
+// specifically so multiple candidate blocks at the same height can coexist
+// during a reorg, so more than one file can match the prefix before
+// finality. This takes whatever Walk returns first, which is not
+// necessarily the canonical (eventually-finalized) fork. Disambiguating
+// against the current head/LIB chain (e.g. via headInfoServer) is tracked
+// as follow-up work; until then, callers querying recent, not-yet-final
+// heights should treat the result as best-effort.
+func (s *blocksHTTPServer) readOneBlockFile(ctx context.Context, num uint64) ([]byte, error) {
+	prefix := fmt.Sprintf("%010d-", num)
+
+	var filename string
+	err := s.store.Walk(ctx, prefix, func(candidate string) error {
+		filename = candidate
+		return errStopWalk
+	})
+	if err != nil && err != errStopWalk {
+		return nil, err
+	}
+	if filename == "" {
+		return nil, fmt.Errorf("no one-block file found")
+	}
+
+	reader, err := s.store.OpenObject(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+func parseBlockRange(path string) (from, to uint64, err error) {
+	if idx := strings.Index(path, "-"); idx > 0 {
+		from, err = strconv.ParseUint(path[:idx], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid range start: %w", err)
+		}
+		to, err = strconv.ParseUint(path[idx+1:], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid range end: %w", err)
+		}
+		if to < from {
+			return 0, 0, fmt.Errorf("range end must be >= start")
+		}
+		return from, to, nil
+	}
+
+	num, err := strconv.ParseUint(path, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid block number: %w", err)
+	}
+	return num, num, nil
+}
+
+// negotiateEncoding wraps w with a gzip or zstd writer based on the
+// request's Accept-Encoding header, falling back to no compression. The
+// returned io.WriteCloser must always be closed by the caller.
+func negotiateEncoding(w http.ResponseWriter, r *http.Request) io.WriteCloser {
+	accept := r.Header.Get("Accept-Encoding")
+
+	switch {
+	case strings.Contains(accept, "zstd"):
+		w.Header().Set("Content-Encoding", "zstd")
+		enc, _ := zstd.NewWriter(w)
+		return enc
+	case strings.Contains(accept, "gzip"):
+		w.Header().Set("Content-Encoding", "gzip")
+		return gzip.NewWriter(w)
+	default:
+		return nopWriteCloser{w}
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseBlockRange(t *testing.T) {
+	cases := []struct {
+		path     string
+		from, to uint64
+		wantErr  bool
+	}{
+		{path: "100", from: 100, to: 100},
+		{path: "100-105", from: 100, to: 105},
+		{path: "105-100", wantErr: true},
+		{path: "abc", wantErr: true},
+		{path: "100-abc", wantErr: true},
+	}
+
+	for _, c := range cases {
+		from, to, err := parseBlockRange(c.path)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseBlockRange(%q): expected error, got none", c.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseBlockRange(%q): unexpected error: %v", c.path, err)
+			continue
+		}
+		if from != c.from || to != c.to {
+			t.Errorf("parseBlockRange(%q) = (%d, %d), want (%d, %d)", c.path, from, to, c.from, c.to)
+		}
+	}
+}
+
+func TestWriteBlockPayload(t *testing.T) {
+	payload := []byte("block-bytes")
+
+	var buf bytes.Buffer
+	if err := writeBlockPayload(&buf, payload, false); err != nil {
+		t.Fatalf("writeBlockPayload() error = %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), payload) {
+		t.Fatalf("writeBlockPayload() without prefix = %v, want %v", buf.Bytes(), payload)
+	}
+
+	buf.Reset()
+	if err := writeBlockPayload(&buf, payload, true); err != nil {
+		t.Fatalf("writeBlockPayload() error = %v", err)
+	}
+	if buf.Len() != 4+len(payload) {
+		t.Fatalf("writeBlockPayload() with prefix wrote %d bytes, want %d", buf.Len(), 4+len(payload))
+	}
+	gotLen := binary.BigEndian.Uint32(buf.Bytes()[:4])
+	if int(gotLen) != len(payload) {
+		t.Fatalf("writeBlockPayload() length prefix = %d, want %d", gotLen, len(payload))
+	}
+	if !bytes.Equal(buf.Bytes()[4:], payload) {
+		t.Fatalf("writeBlockPayload() body = %v, want %v", buf.Bytes()[4:], payload)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+)
+
+const (
+	oneBlockReplicaQueueSize = 1000
+
+	oneBlockReplicaRetryBaseDelay = 500 * time.Millisecond
+	oneBlockReplicaRetryMaxDelay  = 30 * time.Second
+	oneBlockReplicaMaxAttempts    = 10
+)
+
+var (
+	oneBlockWritesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "oneblock_writes_total",
+		Help: "Number of one-block file replica writes, by destination store and result",
+	}, []string{"store", "result"})
+
+	oneBlockWriteLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "oneblock_write_latency_seconds",
+		Help: "Latency of one-block file replica writes, by destination store",
+	}, []string{"store"})
+)
+
+// oneBlockReplicator fans out every produced one-block file to a set of
+// secondary dstore.Store destinations, on top of the primary store already
+// written to by mindreader.MindReaderPlugin. Each destination has its own
+// bounded queue so a slow or unavailable store never blocks the others.
+// enqueue blocks the mindreader hot path only until `write-quorum` copies
+// (primary included) are durable; stragglers keep retrying in the
+// background.
+type oneBlockReplicator struct {
+	primary  dstore.Store
+	suffix   string
+	logger   *zap.Logger
+	quorum   int
+	replicas []*oneBlockReplica
+}
+
+type oneBlockReplica struct {
+	store dstore.Store
+	label string
+	queue chan replicaJob
+	done  chan struct{}
+}
+
+// replicaJob is a one-block file to write to a single replica. ack, when
+// non-nil, receives the write's result (nil on success) so enqueue can wait
+// for quorum; it is nil for work dispatched after quorum was already met,
+// since nobody is waiting on it anymore.
+type replicaJob struct {
+	block *bstream.Block
+	ack   chan<- error
+}
+
+// newOneBlockReplicatorFromFlags builds a oneBlockReplicator out of the
+// `ingestor-oneblock-*` flags. It returns a nil replicator (and no error)
+// when no store URLs are configured, so replication is a no-op by default;
+// a single secondary URL is a perfectly valid RF=2 setup and is replicated
+// just like any other count.
+func newOneBlockReplicatorFromFlags(primaryURL, suffix string, logger *zap.Logger) (*oneBlockReplicator, error) {
+	rawURLs := viper.GetString("ingestor-oneblock-store-urls")
+	if strings.TrimSpace(rawURLs) == "" {
+		return nil, nil
+	}
+
+	urls := strings.Split(rawURLs, ",")
+
+	rf := viper.GetInt("ingestor-oneblock-rf")
+	quorum := viper.GetInt("ingestor-oneblock-write-quorum")
+	if err := validateReplicationConfig(len(urls), rf, quorum); err != nil {
+		return nil, err
+	}
+
+	primary, err := dstore.NewStore(primaryURL, suffix, "", true)
+	if err != nil {
+		return nil, fmt.Errorf("opening primary one-block store %q: %w", primaryURL, err)
+	}
+
+	replicator := &oneBlockReplicator{
+		primary: primary,
+		suffix:  suffix,
+		logger:  logger,
+		quorum:  quorum,
+	}
+
+	for i, url := range urls {
+		store, err := dstore.NewStore(strings.TrimSpace(url), suffix, "", true)
+		if err != nil {
+			return nil, fmt.Errorf("opening one-block replica store %d (%q): %w", i, url, err)
+		}
+
+		replica := &oneBlockReplica{
+			store: store,
+			label: fmt.Sprintf("replica-%d", i),
+			queue: make(chan replicaJob, oneBlockReplicaQueueSize),
+			done:  make(chan struct{}),
+		}
+		replicator.replicas = append(replicator.replicas, replica)
+
+		go replicator.runReplica(replica)
+	}
+
+	return replicator, nil
+}
+
+// validateReplicationConfig checks rf and quorum against the number of
+// secondary store URLs configured. rf counts every copy of a one-block
+// file, including the primary store that mindreader.NewMindReaderPlugin
+// writes to unconditionally; only numSecondaryURLs of those copies are
+// actually fanned out by this replicator.
+func validateReplicationConfig(numSecondaryURLs, rf, quorum int) error {
+	if rf != numSecondaryURLs+1 {
+		return fmt.Errorf("ingestor-oneblock-rf (%d) must equal 1 (primary) + the number of ingestor-oneblock-store-urls entries (%d)", rf, numSecondaryURLs)
+	}
+
+	if quorum < 1 || quorum > rf {
+		return fmt.Errorf("ingestor-oneblock-write-quorum (%d) must be between 1 and ingestor-oneblock-rf (%d)", quorum, rf)
+	}
+
+	return nil
+}
+
+// enqueue schedules block's one-block file for replication to every
+// secondary store and blocks until enough of them ack to reach
+// write-quorum, counting the primary copy (already durable by the time
+// enqueue is called) as one ack. Stores that don't make the cut keep
+// retrying in the background; enqueue never waits on them again.
+func (r *oneBlockReplicator) enqueue(block *bstream.Block) error {
+	needed := r.quorum - 1 // the primary copy already counts as one ack
+	if needed <= 0 || len(r.replicas) == 0 {
+		for _, replica := range r.replicas {
+			r.submit(replica, block, nil)
+		}
+		return nil
+	}
+
+	results := make(chan error, len(r.replicas))
+	for _, replica := range r.replicas {
+		r.submit(replica, block, results)
+	}
+
+	acked := 0
+	for i := 0; i < len(r.replicas); i++ {
+		if <-results == nil {
+			acked++
+			if acked >= needed {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("one-block write quorum not reached for block %d: needed %d replica ack(s) (plus primary), got %d", block.Num(), needed, acked)
+}
+
+// submit hands a block off to a single replica's bounded queue. A full
+// queue means that replica is already falling behind: the block is dropped
+// for that store (and reported as a failed ack, if anyone is waiting)
+// rather than stalling the hot path.
+func (r *oneBlockReplicator) submit(replica *oneBlockReplica, block *bstream.Block, ack chan<- error) {
+	select {
+	case replica.queue <- replicaJob{block: block, ack: ack}:
+	default:
+		r.logger.Warn("one-block replica queue is full, dropping block",
+			zap.String("store", replica.label),
+			zap.Uint64("block_num", block.Num()),
+		)
+		oneBlockWritesTotal.WithLabelValues(replica.label, "dropped").Inc()
+		if ack != nil {
+			ack <- fmt.Errorf("replica queue full")
+		}
+	}
+}
+
+func (r *oneBlockReplicator) runReplica(replica *oneBlockReplica) {
+	defer close(replica.done)
+
+	for job := range replica.queue {
+		start := time.Now()
+		err := r.replicateOne(replica, job.block)
+		if err != nil {
+			r.logger.Warn("giving up replicating one-block file",
+				zap.String("store", replica.label),
+				zap.Uint64("block_num", job.block.Num()),
+				zap.Error(err),
+			)
+			oneBlockWritesTotal.WithLabelValues(replica.label, "failure").Inc()
+		} else {
+			oneBlockWriteLatency.WithLabelValues(replica.label).Observe(time.Since(start).Seconds())
+			oneBlockWritesTotal.WithLabelValues(replica.label, "success").Inc()
+		}
+
+		if job.ack != nil {
+			job.ack <- err
+		}
+	}
+}
+
+// replicateOne copies the already-written one-block file from the primary
+// store to a single replica, retrying with exponential backoff.
+func (r *oneBlockReplicator) replicateOne(replica *oneBlockReplica, block *bstream.Block) error {
+	name := oneBlockFileName(block, r.suffix)
+
+	ctx := context.Background()
+	delay := oneBlockReplicaRetryBaseDelay
+
+	var lastErr error
+	for attempt := 0; attempt < oneBlockReplicaMaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+			if delay > oneBlockReplicaRetryMaxDelay {
+				delay = oneBlockReplicaRetryMaxDelay
+			}
+		}
+
+		reader, err := r.primary.OpenObject(ctx, name)
+		if err != nil {
+			lastErr = fmt.Errorf("opening %q from primary store: %w", name, err)
+			continue
+		}
+
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(reader)
+		reader.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("reading %q from primary store: %w", name, err)
+			continue
+		}
+
+		if err := replica.store.WriteObject(ctx, name, bytes.NewReader(buf.Bytes())); err != nil {
+			lastErr = fmt.Errorf("writing %q to %s: %w", name, replica.label, err)
+			continue
+		}
+
+		return nil
+	}
+
+	return lastErr
+}
+
+// close stops accepting new blocks and waits for in-flight writes on each
+// replica to drain.
+func (r *oneBlockReplicator) close() {
+	for _, replica := range r.replicas {
+		close(replica.queue)
+	}
+	for _, replica := range r.replicas {
+		<-replica.done
+	}
+}
+
+// oneBlockFileName mirrors the naming scheme used by the mind reader's
+// one-block writer (num-ID-previousID-libNum-suffix), so replicas read back
+// the exact object the primary store just received.
+func oneBlockFileName(block *bstream.Block, suffix string) string {
+	return fmt.Sprintf("%010d-%s-%s-%d-%s.dbin.zst", block.Num(), block.ID(), block.PreviousID(), block.LibNum, suffix)
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestValidateReplicationConfig(t *testing.T) {
+	cases := []struct {
+		name             string
+		numSecondaryURLs int
+		rf               int
+		quorum           int
+		wantErr          bool
+	}{
+		{name: "single secondary is valid RF=2", numSecondaryURLs: 1, rf: 2, quorum: 2},
+		{name: "two secondaries, quorum below rf", numSecondaryURLs: 2, rf: 3, quorum: 2},
+		{name: "rf not matching secondary count", numSecondaryURLs: 1, rf: 3, quorum: 2, wantErr: true},
+		{name: "quorum below 1", numSecondaryURLs: 1, rf: 2, quorum: 0, wantErr: true},
+		{name: "quorum above rf", numSecondaryURLs: 1, rf: 2, quorum: 3, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateReplicationConfig(c.numSecondaryURLs, c.rf, c.quorum)
+			if c.wantErr && err == nil {
+				t.Fatalf("expected an error, got nil")
+			}
+			if !c.wantErr && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/streamingfast/node-manager/mindreader"
+	"go.uber.org/zap"
+)
+
+// nodeSupervisorState tracks whether the spawned node is expected to be
+// running, deliberately held down for maintenance, or shut down for good.
+type nodeSupervisorState int
+
+const (
+	nodeStateRunning nodeSupervisorState = iota
+	nodeStateMaintenance
+	nodeStateShutdown
+	nodeStateCrashed
+)
+
+func (s nodeSupervisorState) String() string {
+	switch s {
+	case nodeStateRunning:
+		return "running"
+	case nodeStateMaintenance:
+		return "maintenance"
+	case nodeStateShutdown:
+		return "shutdown"
+	case nodeStateCrashed:
+		return "crashed"
+	default:
+		return "unknown"
+	}
+}
+
+// restartTimeout bounds how long a `sync=true` resume call waits for the
+// child process to come back up (or fail) before giving up on the wait.
+const restartTimeout = 30 * time.Second
+
+// nodeStopGracePeriod bounds how long stopLocked waits for a SIGTERM'd node
+// to exit on its own before escalating to SIGKILL. Chain nodes may need to
+// flush state to disk on a clean shutdown, so every maintenance toggle or
+// extra-env resume gets a chance at a graceful exit first.
+const nodeStopGracePeriod = 10 * time.Second
+
+type nodeSupervisorOptions struct {
+	binPath string
+	dir     string
+	args    string
+	env     string
+
+	mrp *mindreader.MindReaderPlugin
+
+	onShutdownRequested func()
+
+	logger *zap.Logger
+}
+
+// nodeSupervisor owns the lifecycle of the node binary spawned in
+// `--ingestor-mode=node`. It lets the HTTP control API stop the node for
+// maintenance and resume it later, optionally with env overrides that only
+// apply to the next `exec`, without going through a full ingestor restart.
+type nodeSupervisor struct {
+	opts nodeSupervisorOptions
+
+	mu      sync.Mutex
+	state   nodeSupervisorState
+	running *runningNode
+}
+
+// runningNode is the currently spawned process plus a channel closed once
+// pumpLines has observed its exit, letting stopLocked wait for a graceful
+// shutdown without calling cmd.Wait() a second time.
+type runningNode struct {
+	cmd    *exec.Cmd
+	exited chan struct{}
+}
+
+func newNodeSupervisor(opts nodeSupervisorOptions) *nodeSupervisor {
+	return &nodeSupervisor{opts: opts}
+}
+
+// resume (re)spawns the node binary. extraEnv, when set, is merged on top of
+// `--ingestor-node-env` for this exec only; it is never persisted, so the
+// next restart (e.g. triggered by a crash or a later resume call without
+// overrides) goes back to the base env. When sync is true, resume blocks
+// until the process is up (first line read from its stdout) or it fails to
+// start/exits immediately.
+func (s *nodeSupervisor) resume(extraEnv []string, sync bool) error {
+	s.mu.Lock()
+	if s.state == nodeStateShutdown {
+		s.mu.Unlock()
+		return errors.New("ingestor node supervisor is shut down")
+	}
+	s.state = nodeStateRunning
+	s.mu.Unlock()
+
+	s.stopLocked()
+
+	args := strings.Fields(s.opts.args)
+	cmd := exec.Command(s.opts.binPath, args...)
+	cmd.Dir = s.opts.dir
+	cmd.Env = mergeEnv(strings.Fields(s.opts.env), extraEnv)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("getting node stdout pipe: %w", err)
+	}
+
+	started := make(chan error, 1)
+	exited := make(chan struct{})
+	go s.pumpLines(cmd, stdout, started, exited)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting node: %w", err)
+	}
+
+	s.mu.Lock()
+	s.running = &runningNode{cmd: cmd, exited: exited}
+	s.mu.Unlock()
+
+	if !sync {
+		return nil
+	}
+
+	select {
+	case err := <-started:
+		return err
+	case <-time.After(restartTimeout):
+		return fmt.Errorf("node did not report readiness within %s", restartTimeout)
+	}
+}
+
+// pumpLines feeds each stdout line of the spawned node to the mind reader
+// plugin, and reports on `started` as soon as the process is confirmed up
+// (first line seen) or has failed (scanner ended with no line read).
+func (s *nodeSupervisor) pumpLines(cmd *exec.Cmd, stdout io.Reader, started chan<- error, exited chan<- struct{}) {
+	scanner := bufio.NewScanner(stdout)
+	reported := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !reported {
+			reported = true
+			started <- nil
+		}
+		s.opts.mrp.LogLine(line)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		s.opts.logger.Warn("node process exited", zap.Error(err))
+	}
+
+	if !reported {
+		started <- fmt.Errorf("node exited before producing any output")
+	}
+
+	s.markExited(cmd)
+	close(exited)
+}
+
+// markExited clears the supervisor's reference to cmd and, if the node was
+// expected to be running (i.e. nobody called maintenance/shutdown/resume in
+// the meantime), flips the state to crashed so healthz stops reporting the
+// node as up.
+func (s *nodeSupervisor) markExited(cmd *exec.Cmd) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running == nil || s.running.cmd != cmd {
+		// Already superseded by a later resume; nothing to do.
+		return
+	}
+
+	s.running = nil
+	if s.state == nodeStateRunning {
+		s.state = nodeStateCrashed
+	}
+}
+
+// maintenance stops the node and keeps it down until resume is called again.
+func (s *nodeSupervisor) maintenance() error {
+	s.mu.Lock()
+	if s.state == nodeStateShutdown {
+		s.mu.Unlock()
+		return errors.New("ingestor node supervisor is shut down")
+	}
+	s.state = nodeStateMaintenance
+	s.mu.Unlock()
+
+	s.stopLocked()
+	return nil
+}
+
+// shutdown stops the node for good and notifies the ingestor app to
+// terminate.
+func (s *nodeSupervisor) shutdown() {
+	s.mu.Lock()
+	s.state = nodeStateShutdown
+	s.mu.Unlock()
+
+	s.stopLocked()
+
+	if s.opts.onShutdownRequested != nil {
+		s.opts.onShutdownRequested()
+	}
+}
+
+// stopLocked asks the running node to exit cleanly (SIGTERM) and only
+// resorts to SIGKILL if it hasn't exited within nodeStopGracePeriod. This
+// supervises a chain node process, so a forced kill on every maintenance
+// toggle or extra-env resume risks corrupting on-disk chain state.
+func (s *nodeSupervisor) stopLocked() {
+	s.mu.Lock()
+	running := s.running
+	s.running = nil
+	s.mu.Unlock()
+
+	if running == nil || running.cmd.Process == nil {
+		return
+	}
+
+	if err := running.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		s.opts.logger.Warn("failed sending SIGTERM to node process, killing instead", zap.Error(err))
+		s.killProcess(running.cmd)
+		return
+	}
+
+	select {
+	case <-running.exited:
+	case <-time.After(nodeStopGracePeriod):
+		s.opts.logger.Warn("node did not exit within grace period after SIGTERM, killing",
+			zap.Duration("grace_period", nodeStopGracePeriod),
+		)
+		s.killProcess(running.cmd)
+	}
+}
+
+func (s *nodeSupervisor) killProcess(cmd *exec.Cmd) {
+	if err := cmd.Process.Kill(); err != nil {
+		s.opts.logger.Warn("failed killing node process", zap.Error(err))
+	}
+}
+
+func (s *nodeSupervisor) healthz() (healthy bool, state nodeSupervisorState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state == nodeStateRunning && s.running != nil, s.state
+}
+
+// mergeEnv overlays extraEnv on top of baseEnv, matching the last-wins
+// semantics of a process environment (`KEY=VALUE` pairs).
+func mergeEnv(baseEnv, extraEnv []string) []string {
+	merged := map[string]string{}
+	order := []string{}
+
+	apply := func(entries []string) {
+		for _, entry := range entries {
+			key, value, ok := strings.Cut(entry, "=")
+			if !ok {
+				continue
+			}
+			if _, exists := merged[key]; !exists {
+				order = append(order, key)
+			}
+			merged[key] = value
+		}
+	}
+
+	apply(baseEnv)
+	apply(extraEnv)
+
+	out := make([]string, 0, len(order))
+	for _, key := range order {
+		out = append(out, key+"="+merged[key])
+	}
+	return out
+}
+
+func (s *nodeSupervisor) serveHTTP(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/maintenance", s.handleMaintenance)
+	mux.HandleFunc("/v1/resume", s.handleResume)
+	mux.HandleFunc("/v1/shutdown", s.handleShutdown)
+	mux.HandleFunc("/v1/healthz", s.handleHealthz)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+func (s *nodeSupervisor) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.maintenance(); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *nodeSupervisor) handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sync := r.URL.Query().Get("sync") == "true"
+	extraEnv := r.URL.Query()["extra-env"]
+
+	if err := s.resume(extraEnv, sync); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *nodeSupervisor) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.shutdown()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *nodeSupervisor) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	healthy, state := s.healthz()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"state": state.String(),
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestMergeEnv(t *testing.T) {
+	base := []string{"A=1", "B=2"}
+	extra := []string{"B=override", "C=3"}
+
+	got := mergeEnv(base, extra)
+	want := []string{"A=1", "B=override", "C=3"}
+
+	if len(got) != len(want) {
+		t.Fatalf("mergeEnv() = %v, want %v", got, want)
+	}
+	for i, entry := range want {
+		if got[i] != entry {
+			t.Fatalf("mergeEnv()[%d] = %q, want %q", i, got[i], entry)
+		}
+	}
+}
+
+func TestNodeSupervisorStateString(t *testing.T) {
+	cases := map[nodeSupervisorState]string{
+		nodeStateRunning:     "running",
+		nodeStateMaintenance: "maintenance",
+		nodeStateShutdown:    "shutdown",
+		nodeStateCrashed:     "crashed",
+		nodeSupervisorState(99): "unknown",
+	}
+
+	for state, want := range cases {
+		if got := state.String(); got != want {
+			t.Errorf("nodeSupervisorState(%d).String() = %q, want %q", state, got, want)
+		}
+	}
+}
+
+// TestNodeSupervisorStopLockedSendsSigtermFirst spawns a process that only
+// exits cleanly on SIGTERM, then asserts stopLocked lets it do so instead of
+// immediately reaching for SIGKILL.
+func TestNodeSupervisorStopLockedSendsSigtermFirst(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "node.sh")
+	contents := "#!/bin/sh\ntrap 'exit 0' TERM\nwhile true; do sleep 0.05; done\n"
+	if err := os.WriteFile(script, []byte(contents), 0o755); err != nil {
+		t.Fatalf("writing fake node script: %v", err)
+	}
+
+	s := newNodeSupervisor(nodeSupervisorOptions{
+		binPath: script,
+		logger:  zap.NewNop(),
+		mrp:     nil,
+	})
+
+	if err := s.resume(nil, false); err != nil {
+		t.Fatalf("resume() error = %v", err)
+	}
+
+	// Give the process a moment to install its trap before we signal it.
+	time.Sleep(100 * time.Millisecond)
+
+	s.mu.Lock()
+	cmd := s.running.cmd
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.stopLocked()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(nodeStopGracePeriod):
+		t.Fatal("stopLocked() did not return promptly after the node honored SIGTERM")
+	}
+
+	// Only the TERM trap exits with status 0; a SIGKILL would not.
+	if cmd.ProcessState == nil || !cmd.ProcessState.Success() {
+		t.Fatalf("node did not exit cleanly via its SIGTERM trap, process state = %v", cmd.ProcessState)
+	}
+}